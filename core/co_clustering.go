@@ -3,25 +3,45 @@ package core
 import (
 	"gonum.org/v1/gonum/floats"
 	"math"
+	"runtime"
+	"sync"
 )
 
 // CoClustering: Collaborative filtering based on co-clustering[5].
 type CoClustering struct {
 	Base
-	GlobalMean       float64     // A^{global}
-	UserMeans        []float64   // A^{R}
-	ItemMeans        []float64   // A^{R}
-	UserClusters     []int       // p(i)
-	ItemClusters     []int       // y(i)
-	UserClusterMeans []float64   // A^{RC}
-	ItemClusterMeans []float64   // A^{CC}
-	CoClusterMeans   [][]float64 // A^{COC}
+	GlobalMean         float64     // A^{global}
+	UserMeans          []float64   // A^{R}
+	ItemMeans          []float64   // A^{C}
+	UserClusters       []int       // p(i)
+	ItemClusters       []int       // y(i)
+	UserClusterMeans   []float64   // A^{RC}
+	ItemClusterMeans   []float64   // A^{CC}
+	CoClusterMeans     [][]float64 // A^{COC}
+	NEpochs            int         // number of epochs actually run before Fit stopped
+	ApproxErrors       []float64   // sum of squared residuals over observed ratings, one entry per epoch run
+	CoClusterVariances [][]float64 // S^2_{gh}, sample variance of ratings in each co-cluster block
+	GlobalVariance     float64     // sample variance of all ratings, used as a cold-start fallback for PredictWithInterval
+	// Running counts kept alongside the exported means above so Update can
+	// refresh them with count-weighted averages instead of a full Fit.
+	userRatingCounts     []float64
+	itemRatingCounts     []float64
+	userClusterCounts    []float64
+	itemClusterCounts    []float64
+	coClusterCounts      [][]float64
+	coClusterM2          [][]float64 // running sum of squared deviations backing CoClusterVariances; CoClusterVariances[g][h] == coClusterM2[g][h] / coClusterCounts[g][h]
+	updatesSinceReassign int
 }
 
 // NewCoClustering creates a co-clustering model. Params:
 //   nEpochs       - The number of iteration of the SGD procedure. Default is 20.
 //   nUserClusters - The number of user clusters. Default is 3.
 //   nItemClusters - The number of item clusters. Default is 3.
+//   init          - The cluster initialization strategy, "random" or "kmeans++". Default is "random".
+//   tol           - The maximum number of cluster membership changes tolerated for convergence. Default is 2.
+//   nStableEpochs - The number of consecutive epochs within tol required to declare convergence. Default is 2.
+//   nJobs         - The number of goroutines used to partition cluster-assignment updates. Default is runtime.GOMAXPROCS(0). 1 disables parallelism.
+//   reassignEvery - The number of Update calls between localized reassignment of the touched user/item. Default is 50. 0 disables reassignment.
 //   randState     - The random seed. Default is UNIX time step.
 func NewCoClustering(params Params) *CoClustering {
 	cc := new(CoClustering)
@@ -62,14 +82,13 @@ func (coc *CoClustering) Fit(trainSet TrainSet) {
 	nUserClusters := coc.Params.GetInt("nUserClusters", 3)
 	nItemClusters := coc.Params.GetInt("nItemClusters", 3)
 	nEpochs := coc.Params.GetInt("nEpochs", 20)
+	nJobs := coc.Params.GetInt("nJobs", runtime.GOMAXPROCS(0))
 	// Initialize parameters
 	coc.GlobalMean = trainSet.GlobalMean
 	userRatings := trainSet.UserRatings()
 	itemRatings := trainSet.ItemRatings()
 	coc.UserMeans = means(userRatings)
 	coc.ItemMeans = means(itemRatings)
-	coc.UserClusters = coc.rng.MakeUniformVectorInt(trainSet.UserCount, 0, nUserClusters)
-	coc.ItemClusters = coc.rng.MakeUniformVectorInt(trainSet.ItemCount, 0, nItemClusters)
 	coc.UserClusterMeans = make([]float64, nUserClusters)
 	coc.ItemClusterMeans = make([]float64, nItemClusters)
 	coc.CoClusterMeans = newZeroMatrix(nUserClusters, nItemClusters)
@@ -80,8 +99,25 @@ func (coc *CoClustering) Fit(trainSet TrainSet) {
 			tmp1[i][idRating.Id] = idRating.Rating - coc.UserMeans[i] - coc.ItemMeans[idRating.Id]
 		}
 	}
+	// Seed initial cluster assignments
+	switch coc.Params.GetString("init", "random") {
+	case "kmeans++":
+		coc.UserClusters = coc.kMeansPPInit(tmp1, nUserClusters)
+		coc.ItemClusters = coc.kMeansPPInit(transposeMatrix(tmp1), nItemClusters)
+	default:
+		coc.UserClusters = coc.rng.MakeUniformVectorInt(trainSet.UserCount, 0, nUserClusters)
+		coc.ItemClusters = coc.rng.MakeUniformVectorInt(trainSet.ItemCount, 0, nItemClusters)
+	}
 	// Clustering
+	tol := coc.Params.GetInt("tol", 2)
+	nStableEpochs := coc.Params.GetInt("nStableEpochs", 2)
+	stableEpochs := 0
+	prevUserClusters := make([]int, trainSet.UserCount)
+	prevItemClusters := make([]int, trainSet.ItemCount)
+	coc.ApproxErrors = make([]float64, 0, nEpochs)
 	for ep := 0; ep < nEpochs; ep++ {
+		copy(prevUserClusters, coc.UserClusters)
+		copy(prevItemClusters, coc.ItemClusters)
 		// Compute averages A^{COC}, A^{RC}, A^{CC}, A^R, A^C
 		clusterMean(coc.UserClusterMeans, coc.UserClusters, userRatings)
 		clusterMean(coc.ItemClusterMeans, coc.ItemClusters, itemRatings)
@@ -89,7 +125,7 @@ func (coc *CoClustering) Fit(trainSet TrainSet) {
 		// A^{tmp2}_{ih} = \frac {\sum_{j'|y(j')=h}A^{tmp1}_{ij'}} {\sum_{j'|y(j')=h}W_{ij'}} + A^{CC}_h
 		tmp2 := newZeroMatrix(trainSet.UserCount, nItemClusters)
 		count2 := newZeroMatrix(trainSet.UserCount, nItemClusters)
-		for i := range tmp2 {
+		parallelFor(trainSet.UserCount, nJobs, func(i int) {
 			for _, ir := range userRatings[i] {
 				itemClass := coc.ItemClusters[ir.Id]
 				tmp2[i][itemClass] += tmp1[i][ir.Id]
@@ -99,9 +135,9 @@ func (coc *CoClustering) Fit(trainSet TrainSet) {
 				tmp2[i][h] /= count2[i][h]
 				tmp2[i][h] += coc.ItemClusterMeans[h]
 			}
-		}
+		})
 		// Update row (user) cluster assignments
-		for i := range coc.UserClusters {
+		parallelFor(trainSet.UserCount, nJobs, func(i int) {
 			bestCluster, leastCost := coc.UserClusters[i], math.Inf(1)
 			for g := 0; g < nUserClusters; g++ {
 				// \sum^l_{h=1} A^{tmp2}_{ig} - A^{COC}_{gh} + A^{RC}_g
@@ -118,11 +154,11 @@ func (coc *CoClustering) Fit(trainSet TrainSet) {
 				}
 			}
 			coc.UserClusters[i] = bestCluster
-		}
+		})
 		// A^{tmp3}_{gj} = \frac {\sum_{i'|p(i')=g}A^{tmp1}_{i'j}} {\sum_{i'|p(i')=g}W_{i'j}} + A^{RC}_g
 		tmp3 := newZeroMatrix(nUserClusters, trainSet.ItemCount)
 		count3 := newZeroMatrix(nUserClusters, trainSet.ItemCount)
-		for j := range coc.ItemClusters {
+		parallelFor(trainSet.ItemCount, nJobs, func(j int) {
 			for _, ur := range itemRatings[j] {
 				userClass := coc.UserClusters[ur.Id]
 				tmp3[userClass][j] += tmp1[ur.Id][j]
@@ -132,9 +168,9 @@ func (coc *CoClustering) Fit(trainSet TrainSet) {
 				tmp3[g][j] /= count3[g][j]
 				tmp3[g][j] += coc.UserClusterMeans[g]
 			}
-		}
+		})
 		// Update column (item) cluster assignments
-		for j := range coc.ItemClusters {
+		parallelFor(trainSet.ItemCount, nJobs, func(j int) {
 			bestCluster, leastCost := coc.ItemClusters[j], math.Inf(1)
 			for h := 0; h < nItemClusters; h++ {
 				// \sum^k_{h=1} A^{tmp3}_{gj} - A^{COC}_{gh} + A^{CC}_h
@@ -151,8 +187,475 @@ func (coc *CoClustering) Fit(trainSet TrainSet) {
 				}
 			}
 			coc.ItemClusters[j] = bestCluster
+		})
+		coc.NEpochs = ep + 1
+		coc.ApproxErrors = append(coc.ApproxErrors, coc.approxError(userRatings))
+		// Stop once cluster membership has stabilized for nStableEpochs in a row.
+		changes := countChanges(prevUserClusters, coc.UserClusters) + countChanges(prevItemClusters, coc.ItemClusters)
+		if changes <= tol {
+			stableEpochs++
+			if stableEpochs >= nStableEpochs {
+				break
+			}
+		} else {
+			stableEpochs = 0
+		}
+	}
+	// Recompute the means for the final assignment, then keep running counts
+	// alongside them for Update.
+	clusterMean(coc.UserClusterMeans, coc.UserClusters, userRatings)
+	clusterMean(coc.ItemClusterMeans, coc.ItemClusters, itemRatings)
+	coClusterMean(coc.CoClusterMeans, coc.UserClusters, coc.ItemClusters, userRatings)
+	coc.userRatingCounts = ratingCounts(userRatings)
+	coc.itemRatingCounts = ratingCounts(itemRatings)
+	coc.userClusterCounts = clusterRatingCounts(coc.UserClusters, nUserClusters, userRatings)
+	coc.itemClusterCounts = clusterRatingCounts(coc.ItemClusters, nItemClusters, itemRatings)
+	coc.coClusterCounts = newZeroMatrix(nUserClusters, nItemClusters)
+	for userId, userCluster := range coc.UserClusters {
+		for _, ir := range userRatings[userId] {
+			coc.coClusterCounts[userCluster][coc.ItemClusters[ir.Id]]++
+		}
+	}
+	// One more pass to accumulate per-co-cluster variance for PredictWithInterval.
+	// coClusterM2 is kept alongside CoClusterVariances so Update can fold in
+	// new ratings with Welford's algorithm without losing precision.
+	coc.coClusterM2 = newZeroMatrix(nUserClusters, nItemClusters)
+	for userId, userCluster := range coc.UserClusters {
+		for _, ir := range userRatings[userId] {
+			itemCluster := coc.ItemClusters[ir.Id]
+			residual := ir.Rating - coc.CoClusterMeans[userCluster][itemCluster]
+			coc.coClusterM2[userCluster][itemCluster] += residual * residual
+		}
+	}
+	coc.CoClusterVariances = newZeroMatrix(nUserClusters, nItemClusters)
+	for g := range coc.CoClusterVariances {
+		for h := range coc.CoClusterVariances[g] {
+			if coc.coClusterCounts[g][h] > 0 {
+				coc.CoClusterVariances[g][h] = coc.coClusterM2[g][h] / coc.coClusterCounts[g][h]
+			}
+		}
+	}
+	globalSSE, globalCount := 0.0, 0.0
+	for _, ratings := range userRatings {
+		for _, ir := range ratings {
+			residual := ir.Rating - coc.GlobalMean
+			globalSSE += residual * residual
+			globalCount++
+		}
+	}
+	if globalCount > 0 {
+		coc.GlobalVariance = globalSSE / globalCount
+	}
+}
+
+// PredictWithInterval returns the same point estimate as Predict along with
+// an approximate (alpha confidence) interval built from the per-co-cluster
+// variance accumulated during Fit. Cold-start predictions, which have no
+// co-cluster to draw variance from, fall back to GlobalVariance.
+func (coc *CoClustering) PredictWithInterval(userId, itemId int, alpha float64) (mean, lo, hi float64) {
+	mean = coc.Predict(userId, itemId)
+	innerUserId := coc.UserIdSet.ToDenseId(userId)
+	innerItemId := coc.ItemIdSet.ToDenseId(itemId)
+	variance, count := coc.GlobalVariance, 1.0
+	if innerUserId != NewId && innerItemId != NewId {
+		userCluster := coc.UserClusters[innerUserId]
+		itemCluster := coc.ItemClusters[innerItemId]
+		if n := coc.coClusterCounts[userCluster][itemCluster]; n > 0 {
+			variance, count = coc.CoClusterVariances[userCluster][itemCluster], n
+		}
+	}
+	se := math.Sqrt(variance / count)
+	z := zScore(alpha)
+	return mean, mean - z*se, mean + z*se
+}
+
+// zScore returns the normal-approximation z-score for a two-sided confidence
+// level, e.g. zScore(0.95) ~= 1.96.
+func zScore(alpha float64) float64 {
+	return math.Sqrt2 * math.Erfinv(alpha)
+}
+
+// ratingCounts returns the number of ratings observed for each row of a
+// user-by-item or item-by-user rating list.
+func ratingCounts(idRatings [][]IdRating) []float64 {
+	counts := make([]float64, len(idRatings))
+	for id, ratings := range idRatings {
+		counts[id] = float64(len(ratings))
+	}
+	return counts
+}
+
+// clusterRatingCounts returns, for each cluster, the total number of
+// ratings contributed by the rows assigned to it.
+func clusterRatingCounts(clusters []int, nClusters int, idRatings [][]IdRating) []float64 {
+	counts := make([]float64, nClusters)
+	for id, cluster := range clusters {
+		counts[cluster] += float64(len(idRatings[id]))
+	}
+	return counts
+}
+
+// AddUser registers userId as a known user if it is not already present,
+// seeding its mean rating at the global mean and assigning it to the user
+// cluster whose mean is closest, so it can receive predictions immediately.
+// It is a no-op if userId is already known.
+func (coc *CoClustering) AddUser(userId int) {
+	if coc.UserIdSet.ToDenseId(userId) != NewId {
+		return
+	}
+	coc.UserIdSet.Add(userId)
+	coc.UserMeans = append(coc.UserMeans, coc.GlobalMean)
+	coc.userRatingCounts = append(coc.userRatingCounts, 0)
+	coc.UserClusters = append(coc.UserClusters, nearestCluster(coc.GlobalMean, coc.UserClusterMeans))
+}
+
+// AddItem is the symmetric counterpart of AddUser for items.
+func (coc *CoClustering) AddItem(itemId int) {
+	if coc.ItemIdSet.ToDenseId(itemId) != NewId {
+		return
+	}
+	coc.ItemIdSet.Add(itemId)
+	coc.ItemMeans = append(coc.ItemMeans, coc.GlobalMean)
+	coc.itemRatingCounts = append(coc.itemRatingCounts, 0)
+	coc.ItemClusters = append(coc.ItemClusters, nearestCluster(coc.GlobalMean, coc.ItemClusterMeans))
+}
+
+// Update incorporates a single new rating without a full retrain, following
+// the online k-means pattern of maintaining running centroids and counts.
+// Unknown users/items are registered on the fly via AddUser/AddItem. The
+// user mean, item mean, the two cluster means, and the touched co-cluster
+// mean are all refreshed in place with count-weighted running averages;
+// every reassignEvery updates, the touched user and item are additionally
+// reassigned to their closest cluster under the refreshed means. On a move,
+// the user's or item's whole accumulated mass is migrated between the user or
+// item cluster means, but only this rating is migrated between co-cluster
+// blocks; its older ratings stay counted in the old block.
+func (coc *CoClustering) Update(userId, itemId int, rating float64) {
+	coc.AddUser(userId)
+	coc.AddItem(itemId)
+	innerUserId := coc.UserIdSet.ToDenseId(userId)
+	innerItemId := coc.ItemIdSet.ToDenseId(itemId)
+	coc.UserMeans[innerUserId], coc.userRatingCounts[innerUserId] =
+		runningMean(coc.UserMeans[innerUserId], coc.userRatingCounts[innerUserId], rating)
+	coc.ItemMeans[innerItemId], coc.itemRatingCounts[innerItemId] =
+		runningMean(coc.ItemMeans[innerItemId], coc.itemRatingCounts[innerItemId], rating)
+	userCluster := coc.UserClusters[innerUserId]
+	itemCluster := coc.ItemClusters[innerItemId]
+	coc.UserClusterMeans[userCluster], coc.userClusterCounts[userCluster] =
+		runningMean(coc.UserClusterMeans[userCluster], coc.userClusterCounts[userCluster], rating)
+	coc.ItemClusterMeans[itemCluster], coc.itemClusterCounts[itemCluster] =
+		runningMean(coc.ItemClusterMeans[itemCluster], coc.itemClusterCounts[itemCluster], rating)
+	coc.addCoClusterRating(userCluster, itemCluster, rating)
+	reassignEvery := coc.Params.GetInt("reassignEvery", 50)
+	coc.updatesSinceReassign++
+	if reassignEvery > 0 && coc.updatesSinceReassign >= reassignEvery {
+		adjusted := rating - coc.UserMeans[innerUserId] - coc.ItemMeans[innerItemId]
+		newUserCluster := bestCoCluster(adjusted+coc.ItemClusterMeans[itemCluster], itemCluster, coc.UserClusterMeans, coc.CoClusterMeans, false)
+		newItemCluster := bestCoCluster(adjusted+coc.UserClusterMeans[userCluster], userCluster, coc.ItemClusterMeans, coc.CoClusterMeans, true)
+		if newUserCluster != userCluster {
+			coc.moveUserMass(innerUserId, userCluster, newUserCluster)
+			coc.UserClusters[innerUserId] = newUserCluster
+		}
+		if newItemCluster != itemCluster {
+			coc.moveItemMass(innerItemId, itemCluster, newItemCluster)
+			coc.ItemClusters[innerItemId] = newItemCluster
+		}
+		if newUserCluster != userCluster || newItemCluster != itemCluster {
+			// Only this rating's own weight can be migrated precisely here,
+			// since the joint per-user/item-cluster breakdown of this user's
+			// and item's older ratings isn't retained between Update calls.
+			coc.removeCoClusterRating(userCluster, itemCluster, rating)
+			coc.addCoClusterRating(newUserCluster, newItemCluster, rating)
 		}
+		coc.updatesSinceReassign = 0
+	}
+}
+
+// moveUserMass migrates a user's entire accumulated rating weight from its
+// old user-cluster centroid to its new one, so UserClusterMeans and
+// userClusterCounts stay consistent with the updated cluster label.
+func (coc *CoClustering) moveUserMass(innerUserId, oldCluster, newCluster int) {
+	weight, value := coc.userRatingCounts[innerUserId], coc.UserMeans[innerUserId]
+	addWeightedMass(&coc.UserClusterMeans[oldCluster], &coc.userClusterCounts[oldCluster], -weight, value)
+	addWeightedMass(&coc.UserClusterMeans[newCluster], &coc.userClusterCounts[newCluster], weight, value)
+}
+
+// moveItemMass is the symmetric counterpart of moveUserMass for items.
+func (coc *CoClustering) moveItemMass(innerItemId, oldCluster, newCluster int) {
+	weight, value := coc.itemRatingCounts[innerItemId], coc.ItemMeans[innerItemId]
+	addWeightedMass(&coc.ItemClusterMeans[oldCluster], &coc.itemClusterCounts[oldCluster], -weight, value)
+	addWeightedMass(&coc.ItemClusterMeans[newCluster], &coc.itemClusterCounts[newCluster], weight, value)
+}
+
+// addWeightedMass folds weight*value into the weighted running mean/count
+// pointed to by mean/count. A negative weight removes mass — used to pull a
+// user/item's contribution out of its old cluster on reassignment — and
+// clamps the pair to zero rather than letting count go negative.
+func addWeightedMass(mean, count *float64, weight, value float64) {
+	newCount := *count + weight
+	if newCount <= 0 {
+		*mean, *count = 0, 0
+		return
+	}
+	*mean = ((*mean)*(*count) + value*weight) / newCount
+	*count = newCount
+}
+
+// runningMean folds value into mean/count using the standard running-average
+// update and returns the refreshed mean and count.
+func runningMean(mean, count, value float64) (float64, float64) {
+	count++
+	mean += (value - mean) / count
+	return mean, count
+}
+
+// addCoClusterRating folds rating into the (userCluster, itemCluster) block
+// using Welford's algorithm, keeping CoClusterMeans, coClusterCounts and the
+// M2 accumulator backing CoClusterVariances all in sync, then refreshes the
+// exposed variance for that block.
+func (coc *CoClustering) addCoClusterRating(userCluster, itemCluster int, rating float64) {
+	welfordAdd(&coc.CoClusterMeans[userCluster][itemCluster], &coc.coClusterM2[userCluster][itemCluster],
+		&coc.coClusterCounts[userCluster][itemCluster], rating)
+	coc.refreshCoClusterVariance(userCluster, itemCluster)
+}
+
+// removeCoClusterRating reverses a prior addCoClusterRating(userCluster,
+// itemCluster, rating) call, used when migrating a rating's mass to a
+// different co-cluster block after a localized reassignment.
+func (coc *CoClustering) removeCoClusterRating(userCluster, itemCluster int, rating float64) {
+	welfordRemove(&coc.CoClusterMeans[userCluster][itemCluster], &coc.coClusterM2[userCluster][itemCluster],
+		&coc.coClusterCounts[userCluster][itemCluster], rating)
+	coc.refreshCoClusterVariance(userCluster, itemCluster)
+}
+
+// refreshCoClusterVariance recomputes the exposed CoClusterVariances entry
+// for a block from its M2/count accumulators.
+func (coc *CoClustering) refreshCoClusterVariance(userCluster, itemCluster int) {
+	if n := coc.coClusterCounts[userCluster][itemCluster]; n > 0 {
+		coc.CoClusterVariances[userCluster][itemCluster] = coc.coClusterM2[userCluster][itemCluster] / n
+	} else {
+		coc.CoClusterVariances[userCluster][itemCluster] = 0
+	}
+}
+
+// welfordAdd folds value into the running (mean, m2, count) accumulator
+// using Welford's online algorithm, where m2 is the running sum of squared
+// deviations from the mean, so variance == m2/count.
+func welfordAdd(mean, m2, count *float64, value float64) {
+	*count++
+	delta := value - *mean
+	*mean += delta / *count
+	delta2 := value - *mean
+	*m2 += delta * delta2
+}
+
+// welfordRemove reverses welfordAdd, backing a previously folded value out
+// of the (mean, m2, count) accumulator. Resets the accumulator to zero
+// rather than going negative if count drops to zero.
+func welfordRemove(mean, m2, count *float64, value float64) {
+	if *count <= 1 {
+		*mean, *m2, *count = 0, 0, 0
+		return
+	}
+	oldCount := *count - 1
+	oldMean := (*mean*(*count) - value) / oldCount
+	delta := value - oldMean
+	delta2 := value - *mean
+	*m2 -= delta * delta2
+	*mean = oldMean
+	*count = oldCount
+}
+
+// nearestCluster returns the index of the cluster mean closest to value,
+// used to place a brand-new user/item before it has any ratings of its own.
+func nearestCluster(value float64, clusterMeans []float64) int {
+	best, leastDist := 0, math.Inf(1)
+	for c, mean := range clusterMeans {
+		if d := math.Abs(value - mean); d < leastDist {
+			best, leastDist = c, d
+		}
+	}
+	return best
+}
+
+// bestCoCluster picks the cluster index c minimizing (adjusted -
+// CoClusterMeans[.][.] + ownClusterMeans[c])^2 against the other side's
+// fixed cluster, mirroring the per-observation cost used in Fit; as in Fit's
+// A^{tmp2}/A^{tmp3}, adjusted already includes the other side's cluster mean.
+// transposed
+// selects whether the co-cluster lookup is indexed [other][c] (item lookup)
+// or [c][other] (user lookup).
+func bestCoCluster(adjusted float64, otherCluster int, ownClusterMeans []float64, coClusterMeans [][]float64, transposed bool) int {
+	best, leastCost := 0, math.Inf(1)
+	for c, mean := range ownClusterMeans {
+		var coMean float64
+		if transposed {
+			coMean = coClusterMeans[otherCluster][c]
+		} else {
+			coMean = coClusterMeans[c][otherCluster]
+		}
+		temp := adjusted - coMean + mean
+		if cost := temp * temp; cost < leastCost {
+			best, leastCost = c, cost
+		}
+	}
+	return best
+}
+
+// approxError computes the sum of squared residuals between observed
+// ratings and the co-clustering prediction for the current cluster
+// assignment, used to trace Fit's convergence in ApproxErrors.
+func (coc *CoClustering) approxError(userRatings [][]IdRating) float64 {
+	sse := 0.0
+	for i, ratings := range userRatings {
+		userCluster := coc.UserClusters[i]
+		for _, ir := range ratings {
+			itemCluster := coc.ItemClusters[ir.Id]
+			prediction := coc.UserMeans[i] + coc.ItemMeans[ir.Id] -
+				coc.UserClusterMeans[userCluster] - coc.ItemClusterMeans[itemCluster] +
+				coc.CoClusterMeans[userCluster][itemCluster]
+			residual := ir.Rating - prediction
+			sse += residual * residual
+		}
+	}
+	return sse
+}
+
+// countChanges returns the number of positions where a and b differ.
+func countChanges(a, b []int) int {
+	changes := 0
+	for i := range a {
+		if a[i] != b[i] {
+			changes++
+		}
+	}
+	return changes
+}
+
+// kMeansPPInit seeds nClusters centroids from rows using the k-means++
+// heuristic (each successive centroid is sampled with probability
+// proportional to its squared distance from the nearest centroid already
+// chosen), then assigns every row to its nearest seed. Rows are sparse,
+// with NaN marking missing entries; see sparseSquaredDistance for how those
+// are handled.
+func (coc *CoClustering) kMeansPPInit(rows [][]float64, nClusters int) []int {
+	n := len(rows)
+	clusters := make([]int, n)
+	if n == 0 || nClusters <= 0 {
+		return clusters
+	}
+	seeds := make([][]float64, 0, nClusters)
+	seeds = append(seeds, rows[coc.rng.Intn(n)])
+	minDist := make([]float64, n)
+	for i := range minDist {
+		minDist[i] = math.Inf(1)
+	}
+	for len(seeds) < nClusters {
+		lastSeed := seeds[len(seeds)-1]
+		total := 0.0
+		for i, row := range rows {
+			if d := sparseSquaredDistance(row, lastSeed); d < minDist[i] {
+				minDist[i] = d
+			}
+			total += minDist[i]
+		}
+		if total == 0 {
+			// Every remaining row coincides with a chosen centroid.
+			seeds = append(seeds, rows[coc.rng.Intn(n)])
+			continue
+		}
+		target := coc.rng.Float64() * total
+		cum, chosen := 0.0, n-1
+		for i, d := range minDist {
+			cum += d
+			if cum >= target {
+				chosen = i
+				break
+			}
+		}
+		seeds = append(seeds, rows[chosen])
+	}
+	for i, row := range rows {
+		bestCluster, leastDist := 0, math.Inf(1)
+		for s, seed := range seeds {
+			if d := sparseSquaredDistance(row, seed); d < leastDist {
+				bestCluster, leastDist = s, d
+			}
+		}
+		clusters[i] = bestCluster
+	}
+	return clusters
+}
+
+// sparseSquaredDistance computes the squared Euclidean distance between two
+// sparse, mean-centered rows. A NaN marks a missing rating; since it carries
+// no information about how that entry deviates from its row/column mean, it
+// is treated as 0 (no deviation) rather than skipped, so two rows with
+// disjoint support still compare their full column range instead of
+// trivially scoring a distance of 0.
+func sparseSquaredDistance(a, b []float64) float64 {
+	dist := 0.0
+	for i := range a {
+		av, bv := a[i], b[i]
+		if math.IsNaN(av) {
+			av = 0
+		}
+		if math.IsNaN(bv) {
+			bv = 0
+		}
+		d := av - bv
+		dist += d * d
+	}
+	return dist
+}
+
+// transposeMatrix returns the transpose of m, used to reuse kMeansPPInit for
+// item (column) clustering.
+func transposeMatrix(m [][]float64) [][]float64 {
+	if len(m) == 0 {
+		return nil
+	}
+	t := newNanMatrix(len(m[0]), len(m))
+	for i, row := range m {
+		for j, v := range row {
+			t[j][i] = v
+		}
+	}
+	return t
+}
+
+// parallelFor splits the index range [0,n) into at most nJobs contiguous
+// chunks of ceil(n/nJobs) indices (the last may be shorter) and runs fn over
+// each chunk in its own goroutine, blocking until every
+// goroutine finishes. Each index is only ever handled by one goroutine, so
+// fn may write to index-disjoint slices without additional synchronization.
+// nJobs <= 1 falls back to running fn serially in index order.
+func parallelFor(n, nJobs int, fn func(i int)) {
+	if nJobs <= 1 || n <= 1 {
+		for i := 0; i < n; i++ {
+			fn(i)
+		}
+		return
+	}
+	if nJobs > n {
+		nJobs = n
+	}
+	chunk := (n + nJobs - 1) / nJobs
+	var wg sync.WaitGroup
+	for start := 0; start < n; start += chunk {
+		end := start + chunk
+		if end > n {
+			end = n
+		}
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+			for i := start; i < end; i++ {
+				fn(i)
+			}
+		}(start, end)
 	}
+	wg.Wait()
 }
 
 func clusterMean(dst []float64, clusters []int, idRatings [][]IdRating) {
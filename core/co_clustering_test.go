@@ -0,0 +1,150 @@
+package core
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSparseSquaredDistance(t *testing.T) {
+	nan := math.NaN()
+	// No column is observed in both rows, so intersection-only scoring would
+	// return 0 and hide that the rows differ everywhere they do have data.
+	a := []float64{1, 2, nan}
+	b := []float64{nan, nan, 3}
+	if d := sparseSquaredDistance(a, b); d == 0 {
+		t.Fatalf("expected disjoint-support rows to have nonzero distance, got %v", d)
+	}
+	c := []float64{1, 2, 3}
+	d := []float64{1, 2, 3}
+	if got := sparseSquaredDistance(c, d); got != 0 {
+		t.Fatalf("expected identical rows to have 0 distance, got %v", got)
+	}
+	e := []float64{4, nan}
+	f := []float64{1, nan}
+	if got, want := sparseSquaredDistance(e, f), 9.0; got != want {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+// TestCountChanges covers the membership-change count that Fit's
+// tol/nStableEpochs early-stopping criterion is built on.
+func TestCountChanges(t *testing.T) {
+	a := []int{0, 1, 2, 3}
+	b := []int{0, 1, 5, 3}
+	if got, want := countChanges(a, b), 1; got != want {
+		t.Fatalf("expected %d change, got %d", want, got)
+	}
+	if got := countChanges(a, a); got != 0 {
+		t.Fatalf("expected 0 changes comparing a slice to itself, got %d", got)
+	}
+	c := []int{9, 9, 9, 9}
+	if got, want := countChanges(a, c), 4; got != want {
+		t.Fatalf("expected %d changes, got %d", want, got)
+	}
+}
+
+// TestParallelForMatchesSerial checks that partitioning the cluster-
+// assignment update loops across goroutines produces the same result as the
+// serial nJobs=1 path, for a range of worker counts and an index range that
+// doesn't divide evenly.
+func TestParallelForMatchesSerial(t *testing.T) {
+	const n = 997
+	serial := make([]int, n)
+	parallelFor(n, 1, func(i int) { serial[i] = i * i })
+	for nJobs := 2; nJobs <= 8; nJobs++ {
+		got := make([]int, n)
+		parallelFor(n, nJobs, func(i int) { got[i] = i * i })
+		for i := range got {
+			if got[i] != serial[i] {
+				t.Fatalf("nJobs=%d: index %d = %d, want %d", nJobs, i, got[i], serial[i])
+			}
+		}
+	}
+}
+
+// TestRunningMean checks the count-weighted running average Update uses to
+// refresh UserMeans/ItemMeans and the cluster means.
+func TestRunningMean(t *testing.T) {
+	mean, count := 0.0, 0.0
+	values := []float64{2, 4, 6, 8}
+	for _, v := range values {
+		mean, count = runningMean(mean, count, v)
+	}
+	if count != float64(len(values)) {
+		t.Fatalf("expected count %d, got %v", len(values), count)
+	}
+	if want := 5.0; math.Abs(mean-want) > 1e-9 {
+		t.Fatalf("expected mean %v, got %v", want, mean)
+	}
+}
+
+// TestAddWeightedMass checks that moving a user/item's accumulated weight
+// out of its old cluster and into its new one, as Update's reassignment
+// step does, leaves the weighted mean consistent and reverses cleanly.
+func TestAddWeightedMass(t *testing.T) {
+	mean, count := 2.0, 3.0 // three ratings averaging 2
+	addWeightedMass(&mean, &count, 2, 5)
+	if want := (2.0*3 + 5.0*2) / 5.0; math.Abs(mean-want) > 1e-9 {
+		t.Fatalf("expected mean %v, got %v", want, mean)
+	}
+	if count != 5 {
+		t.Fatalf("expected count 5, got %v", count)
+	}
+	addWeightedMass(&mean, &count, -2, 5)
+	if math.Abs(mean-2.0) > 1e-9 || count != 3 {
+		t.Fatalf("expected mean/count to revert to 2/3, got %v/%v", mean, count)
+	}
+}
+
+// TestWelfordAddRemoveRoundTrip checks that removeCoClusterRating's reverse
+// of welfordAdd keeps the (mean, m2, count) accumulator backing
+// CoClusterVariances exactly in sync with coClusterCounts, rather than
+// letting the numerator go stale while the denominator keeps growing.
+func TestWelfordAddRemoveRoundTrip(t *testing.T) {
+	values := []float64{3, 1, 4, 1, 5, 9}
+
+	mean, m2, count := 0.0, 0.0, 0.0
+	for _, v := range values {
+		welfordAdd(&mean, &m2, &count, v)
+	}
+	for _, v := range values {
+		welfordRemove(&mean, &m2, &count, v)
+	}
+	if mean != 0 || m2 != 0 || count != 0 {
+		t.Fatalf("expected accumulator to return to zero, got mean=%v m2=%v count=%v", mean, m2, count)
+	}
+
+	// Removing the last value added should reproduce the statistics of never
+	// having added it at all.
+	mean, m2, count = 0, 0, 0
+	for _, v := range values[:len(values)-1] {
+		welfordAdd(&mean, &m2, &count, v)
+	}
+	wantMean, wantM2, wantCount := mean, m2, count
+	mean, m2, count = 0, 0, 0
+	for _, v := range values {
+		welfordAdd(&mean, &m2, &count, v)
+	}
+	welfordRemove(&mean, &m2, &count, values[len(values)-1])
+	if math.Abs(mean-wantMean) > 1e-9 || math.Abs(m2-wantM2) > 1e-9 || count != wantCount {
+		t.Fatalf("expected mean=%v m2=%v count=%v, got mean=%v m2=%v count=%v",
+			wantMean, wantM2, wantCount, mean, m2, count)
+	}
+}
+
+// TestZScore checks the normal-approximation z-score PredictWithInterval
+// uses against known two-sided confidence-level values.
+func TestZScore(t *testing.T) {
+	cases := []struct {
+		alpha float64
+		want  float64
+	}{
+		{0.95, 1.959963985},
+		{0.8, 1.2815515655},
+	}
+	for _, c := range cases {
+		if got := zScore(c.alpha); math.Abs(got-c.want) > 1e-6 {
+			t.Fatalf("zScore(%v) = %v, want %v", c.alpha, got, c.want)
+		}
+	}
+}